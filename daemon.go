@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// RunDaemon runs QueryISZCloud on config.Schedule, notifying only on a
+// per-user status transition, and (if config.Listen is set) serves the
+// HTTP query/trigger API alongside the cron loop until ctx is cancelled.
+func RunDaemon(ctx context.Context, store *StateStore) error {
+	schedule := config.Schedule
+	if schedule == "" {
+		schedule = "0 */30 * * * *"
+	}
+
+	c := cron.New(cron.WithSeconds())
+	if _, err := c.AddFunc(schedule, func() {
+		runOnce(ctx, store)
+	}); err != nil {
+		return fmt.Errorf("invalid schedule %q:%w", schedule, err)
+	}
+	c.Start()
+	defer c.Stop()
+
+	var server *APIServer
+	if config.Listen != "" {
+		server = NewAPIServer(config.Listen, store)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("[ERROR] http api server stopped:%v", err)
+			}
+		}()
+	}
+
+	log.Printf("[Info] daemon started, schedule=%q listen=%q", schedule, config.Listen)
+	<-ctx.Done()
+	log.Printf("[Info] daemon shutting down")
+
+	if server != nil {
+		server.Close()
+	}
+	return nil
+}
+
+// runOnce performs one query/notify cycle, notifying only for users whose
+// status changed since the previous cycle.
+func runOnce(ctx context.Context, store *StateStore) {
+	result, err := QueryISZCloud(ctx)
+	if err != nil {
+		log.Printf("[Error] query iszcloud error:%v", err)
+		return
+	}
+
+	var changed []ISZCloudResp
+	for _, r := range result {
+		if store.Transitioned(r) {
+			changed = append(changed, r)
+		}
+	}
+	if err := store.Save(); err != nil {
+		log.Printf("[ERROR] save state error:%v", err)
+	}
+
+	if len(changed) == 0 {
+		log.Printf("[Info] query iszcloud success, no status change, skip notify")
+		return
+	}
+
+	notifiers := BuildNotifiers(config.Notifiers)
+	subject := fmt.Sprintf("[ISZCloud][%v]口罩预约结果", time.Now().Format("2006-01-02"))
+	NotifyAll(ctx, notifiers, subject, fmt.Sprintf("%v", changed), changed)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// APIServer exposes the daemon-mode HTTP API: liveness, the configured user
+// list plus their last known status, live add/remove of users, and an
+// on-demand refresh trigger.
+type APIServer struct {
+	srv   *http.Server
+	store *StateStore
+}
+
+func NewAPIServer(addr string, store *StateStore) *APIServer {
+	s := &APIServer{store: store}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/users", s.handleUsersCollection)
+	mux.HandleFunc("/users/", s.handleUserItem)
+	mux.HandleFunc("/query", s.handleQuery)
+
+	s.srv = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+func (s *APIServer) ListenAndServe() error {
+	return s.srv.ListenAndServe()
+}
+
+func (s *APIServer) Close() error {
+	return s.srv.Close()
+}
+
+func (s *APIServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// GET /users - configured users plus last known status.
+// POST /users - add a user, body: {"mobile":"...","code":"..."}
+func (s *APIServer) handleUsersCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		userInfoMu.RLock()
+		users := make([]User, len(config.UserInfo))
+		copy(users, config.UserInfo)
+		userInfoMu.RUnlock()
+
+		writeJSON(w, http.StatusOK, struct {
+			Users []User      `json:"users"`
+			State []UserState `json:"state"`
+		}{Users: users, State: s.store.Snapshot()})
+
+	case http.MethodPost:
+		var u User
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if u.Mobile == "" {
+			http.Error(w, "mobile is required", http.StatusBadRequest)
+			return
+		}
+
+		userInfoMu.Lock()
+		config.UserInfo = append(config.UserInfo, u)
+		userInfoMu.Unlock()
+
+		writeJSON(w, http.StatusOK, u)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// DELETE /users/{mobile} - remove a configured user.
+func (s *APIServer) handleUserItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mobile := strings.TrimPrefix(r.URL.Path, "/users/")
+	if mobile == "" {
+		http.Error(w, "mobile is required in path", http.StatusBadRequest)
+		return
+	}
+
+	userInfoMu.Lock()
+	kept := config.UserInfo[:0]
+	for _, u := range config.UserInfo {
+		if u.Mobile != mobile {
+			kept = append(kept, u)
+		}
+	}
+	config.UserInfo = kept
+	userInfoMu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /query - trigger an immediate refresh and return the raw results.
+func (s *APIServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := QueryISZCloud(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, res := range result {
+		s.store.Transitioned(res)
+	}
+	if err := s.store.Save(); err != nil {
+		log.Printf("[ERROR] save state error:%v", err)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
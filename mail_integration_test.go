@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DemoLiang/iszcloud/mailservice"
+)
+
+// TestSmtpSenderSendEmailInbucket exercises SmtpSender.SendEmail end to end
+// against a real SMTP server, then round-trips through Inbucket's REST API
+// to assert the delivered message matches what was sent. Requires
+// INBUCKET_URL (and optionally INBUCKET_SMTP_ADDR) to point at a running
+// Inbucket instance; skipped otherwise so `go test ./...` still passes offline.
+func TestSmtpSenderSendEmailInbucket(t *testing.T) {
+	inbucketURL := os.Getenv("INBUCKET_URL")
+	if inbucketURL == "" {
+		t.Skip("INBUCKET_URL not set, skipping Inbucket integration test")
+	}
+
+	smtpAddr := os.Getenv("INBUCKET_SMTP_ADDR")
+	if smtpAddr == "" {
+		smtpAddr = "127.0.0.1:2500"
+	}
+
+	const mailbox = "iszcloud-test"
+	client := mailservice.NewClient(inbucketURL)
+	if err := client.DeleteMailbox(mailbox); err != nil {
+		t.Fatalf("delete mailbox before test:%v", err)
+	}
+
+	config = &GlobalConfig{
+		Mail: Mail{
+			SMTPSmarthost: smtpAddr,
+			SMTPFrom:      "iszcloud@example.com",
+			SmtpTo:        []string{mailbox + "@example.com"},
+		},
+	}
+
+	subject := "口罩预约结果 ① 中奖通知"
+	body := "恭喜你抽中奖了，请尽快到指定地点领取 ①"
+
+	sender := new(SmtpSender)
+	if err := sender.SendEmail(config.Mail.SmtpTo, body, subject, "text"); err != nil {
+		t.Fatalf("SendEmail:%v", err)
+	}
+
+	var headers []mailservice.MessageHeader
+	for attempt := 0; attempt < 10; attempt++ {
+		var err error
+		headers, err = client.ListMessages(mailbox)
+		if err != nil {
+			t.Fatalf("list messages:%v", err)
+		}
+		if len(headers) > 0 {
+			break
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+	if len(headers) == 0 {
+		t.Fatalf("no messages delivered to mailbox %q", mailbox)
+	}
+
+	msg, err := client.GetMessage(mailbox, headers[0].ID)
+	if err != nil {
+		t.Fatalf("get message:%v", err)
+	}
+
+	if msg.Subject != subject {
+		t.Errorf("Subject = %q, want %q", msg.Subject, subject)
+	}
+	if !strings.Contains(msg.From, config.Mail.SMTPFrom) {
+		t.Errorf("From = %q, want to contain %q", msg.From, config.Mail.SMTPFrom)
+	}
+	if len(msg.To) == 0 || !strings.Contains(msg.To[0], mailbox) {
+		t.Errorf("To = %v, want to contain %q", msg.To, mailbox)
+	}
+	if !strings.Contains(msg.Body.Text, body) {
+		t.Errorf("body = %q, want to contain %q", msg.Body.Text, body)
+	}
+
+	if ct := msg.Header.Get("Content-Type"); !strings.Contains(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	if enc := msg.Header.Get("Content-Transfer-Encoding"); enc != "base64" {
+		t.Errorf("Content-Transfer-Encoding = %q, want base64", enc)
+	}
+
+	if err := client.DeleteMailbox(mailbox); err != nil {
+		t.Fatalf("delete mailbox after test:%v", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package mailservice wraps Inbucket's REST API so integration tests can
+// round-trip a sent email: list a mailbox, fetch a message by ID, and clean
+// up afterwards. See https://inbucket.org/apidoc.html.
+package mailservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"time"
+)
+
+// Client talks to a single Inbucket instance at baseURL (e.g. "http://localhost:9000").
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// MessageHeader is one entry of GET /api/v1/mailbox/{name}.
+type MessageHeader struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	To      []string  `json:"to"`
+	Subject string    `json:"subject"`
+	Date    time.Time `json:"date"`
+	Size    int       `json:"size"`
+}
+
+// Message is the full message returned by GET /api/v1/mailbox/{name}/{id}.
+type Message struct {
+	MessageHeader
+	Header textproto.MIMEHeader `json:"header"`
+	Body   struct {
+		Text string `json:"text"`
+		HTML string `json:"html"`
+	} `json:"body"`
+}
+
+// ListMessages returns the headers of every message currently in mailbox.
+func (c *Client) ListMessages(mailbox string) ([]MessageHeader, error) {
+	var headers []MessageHeader
+	if err := c.get(fmt.Sprintf("/api/v1/mailbox/%s", mailbox), &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// GetMessage fetches the full message with the given ID from mailbox.
+func (c *Client) GetMessage(mailbox, id string) (*Message, error) {
+	var msg Message
+	if err := c.get(fmt.Sprintf("/api/v1/mailbox/%s/%s", mailbox, id), &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// DeleteMailbox removes every message in mailbox.
+func (c *Client) DeleteMailbox(mailbox string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+fmt.Sprintf("/api/v1/mailbox/%s", mailbox), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("mailservice: delete mailbox %q: unexpected status %v", mailbox, resp.Status)
+	}
+	return nil
+}
+
+func (c *Client) get(path string, out interface{}) error {
+	resp, err := c.http.Get(c.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("mailservice: GET %s: unexpected status %v", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
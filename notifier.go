@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier delivers a query result through some channel (email, webhook,
+// chat bot, ...). subject/body are already rendered; result is passed
+// alongside so richer notifiers (webhooks, templates) can use the
+// structured fields instead of the flattened body text.
+type Notifier interface {
+	Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error
+}
+
+// NotifierConfig is one entry of GlobalConfig.Notifiers. Type selects the
+// implementation; only the fields relevant to that Type need be set.
+type NotifierConfig struct {
+	Type string `json:"type"`
+
+	// Webhook
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers"`
+	Template string            `json:"template"`
+
+	// ServerChan
+	SendKey string `json:"send_key"`
+
+	// DingTalk / WeCom / Feishu robot webhooks
+	AccessToken string `json:"access_token"`
+	Secret      string `json:"secret"`
+
+	// Telegram
+	BotToken string `json:"bot_token"`
+	ChatID   string `json:"chat_id"`
+}
+
+// CreateNotifier builds the Notifier described by cfg.
+func CreateNotifier(cfg NotifierConfig) (Notifier, error) {
+	switch strings.ToUpper(cfg.Type) {
+	case "", "SMTP":
+		return new(SmtpSender), nil
+	case "WEBHOOK":
+		return NewWebhookNotifier(cfg), nil
+	case "SERVERCHAN":
+		return NewServerChanNotifier(cfg), nil
+	case "DINGTALK":
+		return NewDingTalkNotifier(cfg), nil
+	case "WECOM":
+		return NewWeComNotifier(cfg), nil
+	case "FEISHU":
+		return NewFeishuNotifier(cfg), nil
+	case "TELEGRAM":
+		return NewTelegramNotifier(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type:%v", cfg.Type)
+	}
+}
+
+// BuildNotifiers turns the configured notifier list into Notifiers, logging
+// and skipping any entry that fails to construct. When cfgs is empty it
+// falls back to the legacy single SMTP notifier so existing configs that
+// only set "mail" keep working unchanged.
+func BuildNotifiers(cfgs []NotifierConfig) []Notifier {
+	if len(cfgs) == 0 {
+		return []Notifier{new(SmtpSender)}
+	}
+
+	notifiers := make([]Notifier, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		n, err := CreateNotifier(cfg)
+		if err != nil {
+			log.Printf("[ERROR] skip notifier config %+v:%v", cfg, err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
+
+// NotifyAll fans result out to every notifier concurrently. A failing
+// channel is logged and does not block or fail the others.
+func NotifyAll(ctx context.Context, notifiers []Notifier, subject, body string, result []ISZCloudResp) {
+	var wg sync.WaitGroup
+	for _, n := range notifiers {
+		n := n
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := n.Notify(ctx, subject, body, result); err != nil {
+				log.Printf("[ERROR] notifier %T failed:%v", n, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// postJSON is a small shared helper for the bot-webhook style notifiers
+// (DingTalk, WeCom, Feishu, Telegram) that all speak "POST a JSON body,
+// expect 2xx back".
+func postJSON(ctx context.Context, api string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+// Notify implements Notifier for the pre-existing SMTP sender. It renders
+// the configured (or default) templates into a multipart/alternative
+// message so HTML clients get a table of winners while plain-text clients
+// still see something readable, falling back to the flat text body if
+// template rendering fails for any reason.
+func (sender *SmtpSender) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if len(config.Mail.SmtpTo) == 0 {
+		log.Printf("[DEBUG] not specified email address!")
+		return nil
+	}
+
+	renderCtx := BuildRenderContext(result, time.Now().Format("2006-01-02"))
+	renderedSubject, textBody, htmlBody, err := RenderNotification(config.Mail, renderCtx)
+	if err != nil {
+		log.Printf("[ERROR] render notification templates failed, falling back to plain text:%v", err)
+		return sender.SendEmail(config.Mail.SmtpTo, body, subject, "text")
+	}
+
+	var attachments []Attachment
+	if config.Mail.AttachJSON {
+		raw, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Printf("[ERROR] marshal result for attachment failed:%v", err)
+		} else {
+			attachments = append(attachments, Attachment{
+				Filename:    "result.json",
+				ContentType: "application/json",
+				Data:        raw,
+			})
+		}
+	}
+
+	tos := strings.Join(config.Mail.SmtpTo, ";")
+	return SmtpSendMultipart(config.Mail, config.Mail.SMTPFrom, tos, renderedSubject, textBody, htmlBody, attachments)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DingTalkNotifier sends through a DingTalk (钉钉) custom robot webhook,
+// optionally signing the request when the robot is configured with a secret.
+type DingTalkNotifier struct {
+	cfg NotifierConfig
+}
+
+func NewDingTalkNotifier(cfg NotifierConfig) *DingTalkNotifier {
+	return &DingTalkNotifier{cfg: cfg}
+}
+
+type dingTalkMessage struct {
+	MsgType string `json:"msgtype"`
+	Text    struct {
+		Content string `json:"content"`
+	} `json:"text"`
+}
+
+func (n *DingTalkNotifier) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if n.cfg.AccessToken == "" {
+		return fmt.Errorf("dingtalk: access_token is required")
+	}
+
+	api := fmt.Sprintf("https://oapi.dingtalk.com/robot/send?access_token=%s", n.cfg.AccessToken)
+	if n.cfg.Secret != "" {
+		signed, err := dingTalkSign(api, n.cfg.Secret)
+		if err != nil {
+			return err
+		}
+		api = signed
+	}
+
+	msg := dingTalkMessage{MsgType: "text"}
+	msg.Text.Content = fmt.Sprintf("%s\n%s", subject, body)
+	return postJSON(ctx, api, msg)
+}
+
+// dingTalkSign appends the timestamp+sign query params DingTalk requires
+// when the robot webhook has a secret configured.
+func dingTalkSign(api, secret string) (string, error) {
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	stringToSign := timestamp + "\n" + secret
+
+	h := hmac.New(sha256.New, []byte(secret))
+	if _, err := h.Write([]byte(stringToSign)); err != nil {
+		return "", err
+	}
+	sign := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return api + "&timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDingTalkSign(t *testing.T) {
+	cases := []struct {
+		name   string
+		api    string
+		secret string
+	}{
+		{"basic", "https://oapi.dingtalk.com/robot/send?access_token=abc", "SECabc123"},
+		{"empty secret", "https://oapi.dingtalk.com/robot/send?access_token=abc", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			signed, err := dingTalkSign(c.api, c.secret)
+			if err != nil {
+				t.Fatalf("dingTalkSign:%v", err)
+			}
+
+			if !strings.HasPrefix(signed, c.api+"&timestamp=") {
+				t.Fatalf("signed = %q, want prefix %q", signed, c.api+"&timestamp=")
+			}
+
+			_, rawQuery, ok := strings.Cut(signed, "&")
+			if !ok {
+				t.Fatalf("signed url %q missing query params", signed)
+			}
+			q, err := url.ParseQuery(rawQuery)
+			if err != nil {
+				t.Fatalf("parse query %q:%v", rawQuery, err)
+			}
+			if q.Get("timestamp") == "" {
+				t.Errorf("missing timestamp query param in %q", signed)
+			}
+			if q.Get("sign") == "" {
+				t.Errorf("missing sign query param in %q", signed)
+			}
+		})
+	}
+}
+
+func TestDingTalkSignMatchesHMACOfTimestampAndSecret(t *testing.T) {
+	const secret = "shared-secret"
+
+	signed, err := dingTalkSign("https://example.com", secret)
+	if err != nil {
+		t.Fatalf("dingTalkSign:%v", err)
+	}
+
+	_, rawQuery, ok := strings.Cut(signed, "&")
+	if !ok {
+		t.Fatalf("signed url %q missing query params", signed)
+	}
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatalf("parse query %q:%v", rawQuery, err)
+	}
+	ts := q.Get("timestamp")
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts + "\n" + secret))
+	want := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	got := q.Get("sign")
+	if got != want {
+		t.Errorf("sign = %q, want %q", got, want)
+	}
+}
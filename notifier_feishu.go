@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FeishuNotifier sends through a Feishu/Lark (飞书) group robot webhook,
+// optionally signing the request when the robot is configured with a secret.
+type FeishuNotifier struct {
+	cfg NotifierConfig
+}
+
+func NewFeishuNotifier(cfg NotifierConfig) *FeishuNotifier {
+	return &FeishuNotifier{cfg: cfg}
+}
+
+type feishuMessage struct {
+	MsgType string `json:"msg_type"`
+	Content struct {
+		Text string `json:"text"`
+	} `json:"content"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Sign      string `json:"sign,omitempty"`
+}
+
+func (n *FeishuNotifier) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if n.cfg.AccessToken == "" {
+		return fmt.Errorf("feishu: access_token is required")
+	}
+
+	msg := feishuMessage{MsgType: "text"}
+	msg.Content.Text = fmt.Sprintf("%s\n%s", subject, body)
+
+	if n.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		sign, err := feishuSign(timestamp, n.cfg.Secret)
+		if err != nil {
+			return err
+		}
+		msg.Timestamp = timestamp
+		msg.Sign = sign
+	}
+
+	api := fmt.Sprintf("https://open.feishu.cn/open-apis/bot/v2/hook/%s", n.cfg.AccessToken)
+	return postJSON(ctx, api, msg)
+}
+
+// feishuSign implements Feishu's "timestamp + \n + secret" HMAC-SHA256 scheme.
+func feishuSign(timestamp, secret string) (string, error) {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	if _, err := h.Write([]byte{}); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
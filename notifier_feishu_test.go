@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+func TestFeishuSign(t *testing.T) {
+	cases := []struct {
+		name      string
+		timestamp string
+		secret    string
+	}{
+		{"basic", "1234567890", "SECabc123"},
+		{"empty secret", "1234567890", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := feishuSign(c.timestamp, c.secret)
+			if err != nil {
+				t.Fatalf("feishuSign:%v", err)
+			}
+
+			h := hmac.New(sha256.New, []byte(c.timestamp+"\n"+c.secret))
+			want := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+			if got != want {
+				t.Errorf("feishuSign(%q, %q) = %q, want %q", c.timestamp, c.secret, got, want)
+			}
+		})
+	}
+}
+
+func TestFeishuSignDiffersByTimestamp(t *testing.T) {
+	a, err := feishuSign("1111111111", "secret")
+	if err != nil {
+		t.Fatalf("feishuSign:%v", err)
+	}
+	b, err := feishuSign("2222222222", "secret")
+	if err != nil {
+		t.Fatalf("feishuSign:%v", err)
+	}
+	if a == b {
+		t.Errorf("feishuSign should differ for different timestamps, got %q for both", a)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ServerChanNotifier sends through Server酱 (https://sct.ftqq.com/).
+type ServerChanNotifier struct {
+	cfg NotifierConfig
+}
+
+func NewServerChanNotifier(cfg NotifierConfig) *ServerChanNotifier {
+	return &ServerChanNotifier{cfg: cfg}
+}
+
+func (n *ServerChanNotifier) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if n.cfg.SendKey == "" {
+		return fmt.Errorf("serverchan: send_key is required")
+	}
+
+	api := fmt.Sprintf("https://sctapi.ftqq.com/%s.send", n.cfg.SendKey)
+	form := url.Values{
+		"title": {subject},
+		"desp":  {body},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("serverchan: unexpected status %v", resp.Status)
+	}
+	return nil
+}
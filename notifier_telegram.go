@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// TelegramNotifier sends a message through a Telegram Bot.
+type TelegramNotifier struct {
+	cfg NotifierConfig
+}
+
+func NewTelegramNotifier(cfg NotifierConfig) *TelegramNotifier {
+	return &TelegramNotifier{cfg: cfg}
+}
+
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+func (n *TelegramNotifier) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if n.cfg.BotToken == "" || n.cfg.ChatID == "" {
+		return fmt.Errorf("telegram: bot_token and chat_id are required")
+	}
+
+	api := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.cfg.BotToken)
+	msg := telegramMessage{ChatID: n.cfg.ChatID, Text: fmt.Sprintf("%s\n%s", subject, body)}
+	return postJSON(ctx, api, msg)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// WebhookNotifier POSTs a user-templated JSON payload to an arbitrary URL.
+type WebhookNotifier struct {
+	cfg NotifierConfig
+}
+
+func NewWebhookNotifier(cfg NotifierConfig) *WebhookNotifier {
+	return &WebhookNotifier{cfg: cfg}
+}
+
+// webhookPayload is the data made available to NotifierConfig.Template.
+type webhookPayload struct {
+	Subject string
+	Body    string
+	Result  []ISZCloudResp
+}
+
+const defaultWebhookTemplate = `{"subject":{{.Subject | printf "%q"}},"body":{{.Body | printf "%q"}}}`
+
+func (n *WebhookNotifier) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if n.cfg.URL == "" {
+		return fmt.Errorf("webhook: url is required")
+	}
+
+	tmplText := n.cfg.Template
+	if tmplText == "" {
+		tmplText = defaultWebhookTemplate
+	}
+	tmpl, err := template.New("webhook").Parse(tmplText)
+	if err != nil {
+		return fmt.Errorf("webhook: parse template:%w", err)
+	}
+
+	var buf bytes.Buffer
+	payload := webhookPayload{Subject: subject, Body: body, Result: result}
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return fmt.Errorf("webhook: render template:%w", err)
+	}
+
+	method := n.cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, n.cfg.URL, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %v", resp.Status)
+	}
+	return nil
+}
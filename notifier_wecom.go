@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// WeComNotifier sends through a WeCom (企业微信) group robot webhook.
+type WeComNotifier struct {
+	cfg NotifierConfig
+}
+
+func NewWeComNotifier(cfg NotifierConfig) *WeComNotifier {
+	return &WeComNotifier{cfg: cfg}
+}
+
+type weComMessage struct {
+	MsgType string `json:"msgtype"`
+	Text    struct {
+		Content string `json:"content"`
+	} `json:"text"`
+}
+
+func (n *WeComNotifier) Notify(ctx context.Context, subject, body string, result []ISZCloudResp) error {
+	if n.cfg.AccessToken == "" {
+		return fmt.Errorf("wecom: access_token is required")
+	}
+
+	api := fmt.Sprintf("https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=%s", n.cfg.AccessToken)
+	msg := weComMessage{MsgType: "text"}
+	msg.Text.Content = fmt.Sprintf("%s\n%s", subject, body)
+	return postJSON(ctx, api, msg)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const iszCloudQueryPath = "/service/apply-win-query/%v/%v?cityNo=sz"
+
+// defaultMaxConcurrency bounds how many user queries run at once when
+// config.MaxConcurrency is unset.
+const defaultMaxConcurrency = 5
+
+// backoffSchedule is the jittered exponential backoff used between retries:
+// 500ms, 1s, 2s.
+var backoffSchedule = []time.Duration{500 * time.Millisecond, 1 * time.Second, 2 * time.Second}
+
+// httpStatusError wraps a non-2xx HTTP response so retryWithBackoff can
+// distinguish a 5xx (retryable) from a well-formed 4xx response.
+type httpStatusError struct {
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// decodeError wraps a response-body decode failure so retryWithBackoff
+// treats it like a well-formed (non-retryable) response: a body that
+// doesn't parse as ISZCloudResp won't parse any differently on retry.
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("decode response: %v", e.err)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// newISZCloudClient builds the *http.Client used for every ISZCloud query,
+// with sane timeouts/keep-alives and an optional proxy.
+func newISZCloudClient(proxyURL string) (*http.Client, error) {
+	transport := &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy_url %q:%w", proxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   15 * time.Second,
+	}, nil
+}
+
+// HttpGet issues a single GET, returning an *httpStatusError for a 5xx
+// response so the caller's retry logic can treat it as retryable.
+func HttpGet(ctx context.Context, client *http.Client, reqURL string) (body []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		log.Printf("[Error] new request error:%v", err)
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("[Error] client do error:%v\n", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyByte, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("[Error] ReadAll do error:%v\n", err)
+		return nil, err
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode}
+	}
+	return bodyByte, nil
+}
+
+// isRetryable reports whether err warrants another attempt: network errors
+// (dial failures, timeouts, ...) and 5xx responses, but not a well-formed
+// response that merely decoded into Success=false, nor a body that failed
+// to decode at all (a 4xx error page slipping past HttpGet's >=500 check,
+// for instance, won't parse any differently on retry).
+func isRetryable(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	var decErr *decodeError
+	if errors.As(err, &decErr) {
+		return false
+	}
+	return true
+}
+
+// retryWithBackoff calls fn up to len(backoffSchedule)+1 times total,
+// retrying only isRetryable errors with jittered exponential backoff.
+func retryWithBackoff(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+		if attempt >= len(backoffSchedule) {
+			return err
+		}
+
+		delay := backoffSchedule[attempt]
+		jitter := time.Duration(rand.Int63n(int64(delay) / 2))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay + jitter):
+		}
+	}
+}
+
+// QueryISZCloud queries every configured user concurrently (bounded by
+// config.MaxConcurrency), retrying transient failures with backoff. A
+// single user's persistent failure is logged and excluded from the result
+// rather than failing the whole query; ctx cancellation (e.g. SIGINT in
+// daemon mode) aborts in-flight requests.
+func QueryISZCloud(ctx context.Context) ([]ISZCloudResp, error) {
+	userInfoMu.RLock()
+	users := make([]User, len(config.UserInfo))
+	copy(users, config.UserInfo)
+	userInfoMu.RUnlock()
+
+	client, err := newISZCloudClient(config.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrency := config.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+
+	results := make([]ISZCloudResp, len(users))
+	present := make([]bool, len(users))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrency)
+
+	for idx := range users {
+		idx := idx
+		g.Go(func() error {
+			queryPath := fmt.Sprintf(iszCloudQueryPath, users[idx].Mobile, users[idx].Code)
+
+			var resp ISZCloudResp
+			err := retryWithBackoff(gCtx, func() error {
+				body, err := HttpGet(gCtx, client, config.Server+queryPath)
+				if err != nil {
+					return err
+				}
+				if err := json.Unmarshal(body, &resp); err != nil {
+					return &decodeError{err: err}
+				}
+				return nil
+			})
+			if err != nil {
+				log.Printf("[Error] query user %v error:%v", users[idx].Mobile, err)
+				return nil
+			}
+
+			log.Printf("[Info] ISZCloud :%v", resp.String())
+			results[idx] = resp
+			present[idx] = true
+			return nil
+		})
+	}
+	_ = g.Wait() // per-user errors are swallowed above, never returned here
+
+	result := make([]ISZCloudResp, 0, len(users))
+	for idx, ok := range present {
+		if ok {
+			result = append(result, results[idx])
+		}
+	}
+	return result, nil
+}
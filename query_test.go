@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", errors.New("dial tcp: connection refused"), true},
+		{"5xx status", &httpStatusError{StatusCode: 503}, true},
+		{"4xx status", &httpStatusError{StatusCode: 404}, false},
+		{"wrapped 5xx status", fmt.Errorf("query user: %w", &httpStatusError{StatusCode: 502}), true},
+		{"decode error", &decodeError{err: errors.New("unexpected end of JSON input")}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isRetryable(c.err); got != c.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoffSucceedsWithoutRetry(t *testing.T) {
+	calls := 0
+	err := retryWithBackoff(context.Background(), func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("retryWithBackoff:%v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryWithBackoffDoesNotRetryNonRetryableError(t *testing.T) {
+	calls := 0
+	wantErr := &httpStatusError{StatusCode: 404}
+	err := retryWithBackoff(context.Background(), func() error {
+		calls++
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("retryWithBackoff err = %v, want %v", err, wantErr)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (non-retryable error should not retry)", calls)
+	}
+}
+
+func TestRetryWithBackoffRetriesRetryableError(t *testing.T) {
+	orig := backoffSchedule
+	backoffSchedule = []time.Duration{time.Millisecond, time.Millisecond}
+	defer func() { backoffSchedule = orig }()
+
+	calls := 0
+	wantErr := &httpStatusError{StatusCode: 503}
+	err := retryWithBackoff(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return wantErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("retryWithBackoff:%v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryWithBackoffAbortsOnContextCancel(t *testing.T) {
+	orig := backoffSchedule
+	backoffSchedule = []time.Duration{time.Hour}
+	defer func() { backoffSchedule = orig }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := retryWithBackoff(ctx, func() error {
+		calls++
+		return &httpStatusError{StatusCode: 503}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// SMTP security modes accepted by Mail.SMTPSecurity.
+const (
+	SMTPSecurityNone     = "NONE"
+	SMTPSecuritySTARTTLS = "STARTTLS"
+	SMTPSecuritySSL      = "SSL"
+	// SMTPSecurityLogin is accepted for backward compatibility with configs
+	// written before SMTPAuthMethod existed. It behaves like
+	// SMTPSecurityNone combined with SMTPAuthMethodLogin.
+	SMTPSecurityLogin = "LOGIN"
+)
+
+// SMTP auth mechanisms accepted by Mail.SMTPAuthMethod. Independent of
+// SMTPSecurity, so e.g. STARTTLS+LOGIN (163/QQ) is reachable.
+const (
+	SMTPAuthMethodPlain = "PLAIN"
+	SMTPAuthMethodLogin = "LOGIN"
+)
+
+type SmtpSender struct {
+}
+
+func (sender *SmtpSender) SendEmail(email_addrs []string, content string, Subject string, contentType string) (err error) {
+
+	err = SendSmtpEmail(email_addrs, content, Subject, contentType, config.Mail.SMTPFrom)
+	if err != nil {
+		log.Printf("[ERROR] err=%v\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func SendSmtpEmail(email_addrs []string, content string, Subject string, contentType string, from string) (err error) {
+	if len(email_addrs) == 0 {
+		log.Printf("[DEBUG] not specified email address!")
+		return nil
+	}
+
+	if content == "" {
+		log.Printf("[DEBUG] the content is empty!")
+		return nil
+	}
+
+	tos := strings.Join(email_addrs, ";")
+	log.Printf("[INFO] /sender/mail: contentType=%s, tos=%s, subject=%s, content=%s\n", contentType, tos, Subject, content)
+
+	err = SmtpSendMail(config.Mail, from, tos, Subject, content, contentType)
+	return err
+}
+
+// Attachment is a single multipart/mixed attachment carried by SmtpSendMultipart.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// unencryptedAuth forces smtp.PlainAuth to believe the connection is already
+// secured. This preserves the legacy behaviour for SMTPSecurity == "" / "NONE".
+type unencryptedAuth struct {
+	smtp.Auth
+}
+
+func (a unencryptedAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	s := *server
+	s.TLS = true
+	return a.Auth.Start(&s)
+}
+
+// loginAuth implements the LOGIN SASL mechanism, which net/smtp does not
+// provide but which 163/QQ and other mainland providers require.
+type loginAuth struct {
+	username, password string
+}
+
+func LoginAuth(username, password string) smtp.Auth {
+	return &loginAuth{username: username, password: password}
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", []byte{}, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(string(fromServer)) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("loginAuth: unexpected server challenge %q", fromServer)
+	}
+}
+
+func encodeHeader(s string) string {
+	return fmt.Sprintf("=?UTF-8?B?%s?=", base64.StdEncoding.EncodeToString([]byte(s)))
+}
+
+func contentTypeHeader(contentType string) string {
+	if contentType == "html" {
+		return "text/html; charset=UTF-8"
+	}
+	return "text/plain; charset=UTF-8"
+}
+
+func buildMessage(from, tos, subject, body, contentType string) []byte {
+	header := make(map[string]string)
+	header["From"] = from
+	header["To"] = tos
+	header["Subject"] = encodeHeader(subject)
+	header["MIME-Version"] = "1.0"
+	header["Content-Type"] = contentTypeHeader(contentType)
+	header["Content-Transfer-Encoding"] = "base64"
+
+	message := ""
+	for k, v := range header {
+		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	}
+	message += "\r\n" + base64.StdEncoding.EncodeToString([]byte(body))
+	return []byte(message)
+}
+
+// writeBase64Part writes a multipart part whose body is base64-encoded,
+// matching the encoding the single-part buildMessage already used.
+func writeBase64Part(w *multipart.Writer, header textproto.MIMEHeader, data []byte) error {
+	header.Set("Content-Transfer-Encoding", "base64")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoder := base64.NewEncoder(base64.StdEncoding, part)
+	if _, err := encoder.Write(data); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// buildMultipartMessage assembles a multipart/alternative (text + html)
+// body, wrapped in multipart/mixed with the given attachments when any are
+// present.
+func buildMultipartMessage(from, tos, subject, textBody, htmlBody string, attachments []Attachment) ([]byte, error) {
+	var altBuf bytes.Buffer
+	altWriter := multipart.NewWriter(&altBuf)
+
+	if err := writeBase64Part(altWriter, textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}}, []byte(textBody)); err != nil {
+		return nil, err
+	}
+	if err := writeBase64Part(altWriter, textproto.MIMEHeader{"Content-Type": {"text/html; charset=UTF-8"}}, []byte(htmlBody)); err != nil {
+		return nil, err
+	}
+	if err := altWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	var bodyBuf bytes.Buffer
+	var topContentType string
+
+	if len(attachments) == 0 {
+		topContentType = fmt.Sprintf("multipart/alternative; boundary=%q", altWriter.Boundary())
+		bodyBuf.Write(altBuf.Bytes())
+	} else {
+		mixedWriter := multipart.NewWriter(&bodyBuf)
+		topContentType = fmt.Sprintf("multipart/mixed; boundary=%q", mixedWriter.Boundary())
+
+		altPart, err := mixedWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", altWriter.Boundary())},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := altPart.Write(altBuf.Bytes()); err != nil {
+			return nil, err
+		}
+
+		for _, a := range attachments {
+			attHeader := textproto.MIMEHeader{
+				"Content-Type":        {a.ContentType},
+				"Content-Disposition": {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+			}
+			if err := writeBase64Part(mixedWriter, attHeader, a.Data); err != nil {
+				return nil, err
+			}
+		}
+		if err := mixedWriter.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	header := make(map[string]string)
+	header["From"] = from
+	header["To"] = tos
+	header["Subject"] = encodeHeader(subject)
+	header["MIME-Version"] = "1.0"
+	header["Content-Type"] = topContentType
+
+	var message bytes.Buffer
+	for k, v := range header {
+		fmt.Fprintf(&message, "%s: %s\r\n", k, v)
+	}
+	message.WriteString("\r\n")
+	message.Write(bodyBuf.Bytes())
+	return message.Bytes(), nil
+}
+
+func validateSmtpAddress(address string) (host string, err error) {
+	if address == "" {
+		return "", fmt.Errorf("address is necessary")
+	}
+	hp := strings.Split(address, ":")
+	if len(hp) != 2 {
+		return "", fmt.Errorf("address format error")
+	}
+	return hp[0], nil
+}
+
+func sanitizeTos(tos string) ([]string, error) {
+	arr := strings.Split(tos, ";")
+	safeArr := make([]string, 0, len(arr))
+	for _, a := range arr {
+		if a == "" {
+			continue
+		}
+		safeArr = append(safeArr, a)
+	}
+	if len(safeArr) == 0 {
+		return nil, fmt.Errorf("tos invalid")
+	}
+	return safeArr, nil
+}
+
+// dispatchSmtp delivers an already-built message through the transport
+// selected by mail.SMTPSecurity, authenticating with the mechanism selected
+// by mail.SMTPAuthMethod. The two are independent axes, so e.g. STARTTLS or
+// SSL combined with LOGIN auth (required by 163/QQ, which reject AUTH
+// commands on an unencrypted connection) is reachable:
+//   - SMTPSecurity "" / "NONE": legacy transport over a plaintext
+//     connection (unencryptedAuth tricks PLAIN into believing TLS is
+//     present; LOGIN needs no such trick).
+//   - SMTPSecurity "STARTTLS": smtp.Dial then upgrade with StartTLS before
+//     authenticating.
+//   - SMTPSecurity "SSL": implicit TLS (e.g. port 465) via tls.Dial + smtp.NewClient.
+//   - SMTPSecurity "LOGIN" is a legacy alias, kept for configs written
+//     before SMTPAuthMethod existed: equivalent to "NONE" security with
+//     SMTPAuthMethod "LOGIN".
+//   - SMTPAuthMethod "" / "PLAIN": smtp.PlainAuth. "LOGIN": the LOGIN SASL
+//     mechanism implemented by loginAuth.
+func dispatchSmtp(mail Mail, from string, tos []string, message []byte) error {
+	host, err := validateSmtpAddress(mail.SMTPSmarthost)
+	if err != nil {
+		return err
+	}
+	address := mail.SMTPSmarthost
+
+	serverName := mail.SMTPServerName
+	if serverName == "" {
+		serverName = host
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: mail.SMTPInsecureSkipVerify,
+	}
+
+	username := mail.SMTPAuthUsername
+	password := mail.SMTPAuthPassword
+
+	security := strings.ToUpper(mail.SMTPSecurity)
+	authMethod := strings.ToUpper(mail.SMTPAuthMethod)
+	if security == SMTPSecurityLogin {
+		security = SMTPSecurityNone
+		if authMethod == "" {
+			authMethod = SMTPAuthMethodLogin
+		}
+	}
+
+	newAuth := func() smtp.Auth {
+		if authMethod == SMTPAuthMethodLogin {
+			return LoginAuth(username, password)
+		}
+		return smtp.PlainAuth("", username, password, host)
+	}
+
+	switch security {
+	case SMTPSecuritySTARTTLS:
+		return sendViaClient(address, from, tos, message, func(c *smtp.Client) error {
+			if ok, _ := c.Extension("STARTTLS"); !ok {
+				return fmt.Errorf("smtp: server %v does not advertise STARTTLS", address)
+			}
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+			return c.Auth(newAuth())
+		})
+	case SMTPSecuritySSL:
+		return sendViaImplicitTLS(address, from, tos, message, tlsConfig, newAuth())
+	default:
+		auth := newAuth()
+		if authMethod != SMTPAuthMethodLogin {
+			auth = unencryptedAuth{auth}
+		}
+		log.Printf("smtp.SendMail():%v %v %v %v", address, auth, from, tos)
+		return smtp.SendMail(address, auth, from, tos, message)
+	}
+}
+
+// SmtpSendMail sends a single-part email. See dispatchSmtp for the
+// transport selection rules driven by mail.SMTPSecurity.
+func SmtpSendMail(mail Mail, from, tos, subject, body, contentType string) error {
+	if _, err := validateSmtpAddress(mail.SMTPSmarthost); err != nil {
+		return err
+	}
+	safeArr, err := sanitizeTos(tos)
+	if err != nil {
+		return err
+	}
+	message := buildMessage(from, strings.Join(safeArr, ";"), subject, body, contentType)
+	return dispatchSmtp(mail, from, safeArr, message)
+}
+
+// SmtpSendMultipart sends a multipart/alternative (text + html) email,
+// optionally with attachments, through the same transport as SmtpSendMail.
+func SmtpSendMultipart(mail Mail, from, tos, subject, textBody, htmlBody string, attachments []Attachment) error {
+	if _, err := validateSmtpAddress(mail.SMTPSmarthost); err != nil {
+		return err
+	}
+	safeArr, err := sanitizeTos(tos)
+	if err != nil {
+		return err
+	}
+	message, err := buildMultipartMessage(from, strings.Join(safeArr, ";"), subject, textBody, htmlBody, attachments)
+	if err != nil {
+		return err
+	}
+	return dispatchSmtp(mail, from, safeArr, message)
+}
+
+func sendViaClient(address, from string, tos []string, message []byte, authenticate func(*smtp.Client) error) error {
+	c, err := smtp.Dial(address)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := authenticate(c); err != nil {
+		return err
+	}
+	return deliver(c, from, tos, message)
+}
+
+func sendViaImplicitTLS(address, from string, tos []string, message []byte, tlsConfig *tls.Config, auth smtp.Auth) error {
+	conn, err := tls.Dial("tcp", address, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c, err := smtp.NewClient(conn, tlsConfig.ServerName)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Auth(auth); err != nil {
+		return err
+	}
+	return deliver(c, from, tos, message)
+}
+
+func deliver(c *smtp.Client, from string, tos []string, message []byte) error {
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range tos {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// UserState is the last known draw status for one user, used to decide
+// whether a status transition (and therefore a notification) happened.
+type UserState struct {
+	Mobile string `json:"mobile"`
+	Status string `json:"status"`
+	SendNo string `json:"send_no"`
+}
+
+// StateStore persists per-mobile UserState to a JSON file so daemon mode
+// only notifies on a status transition instead of every poll tick.
+type StateStore struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]UserState
+}
+
+// NewStateStore loads persisted state from path, if set and present. An
+// empty path yields an in-memory-only store (no persistence across runs).
+func NewStateStore(path string) (*StateStore, error) {
+	s := &StateStore{path: path, states: make(map[string]UserState)}
+	if path == "" {
+		return s, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	var states []UserState
+	if err := json.Unmarshal(content, &states); err != nil {
+		return nil, err
+	}
+	for _, st := range states {
+		s.states[st.Mobile] = st
+	}
+	return s, nil
+}
+
+// Transitioned reports whether resp represents a status change from the
+// last persisted state for its mobile number, and records the new state.
+func (s *StateStore) Transitioned(resp ISZCloudResp) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.states[resp.Data.Mobile]
+	changed := !ok || prev.Status != resp.Data.Status || prev.SendNo != resp.Data.SendNo
+	s.states[resp.Data.Mobile] = UserState{
+		Mobile: resp.Data.Mobile,
+		Status: resp.Data.Status,
+		SendNo: resp.Data.SendNo,
+	}
+	return changed
+}
+
+// Save writes the current state to disk. A no-op when the store has no path.
+func (s *StateStore) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	states := make([]UserState, 0, len(s.states))
+	for _, st := range s.states {
+		states = append(states, st)
+	}
+	s.mu.Unlock()
+
+	b, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, b, 0644)
+}
+
+// Snapshot returns a copy of all currently known user states.
+func (s *StateStore) Snapshot() []UserState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]UserState, 0, len(s.states))
+	for _, st := range s.states {
+		out = append(out, st)
+	}
+	return out
+}
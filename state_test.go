@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestStateStoreTransitioned(t *testing.T) {
+	store, err := NewStateStore("")
+	if err != nil {
+		t.Fatalf("NewStateStore:%v", err)
+	}
+
+	mkResp := func(mobile, status, sendNo string) ISZCloudResp {
+		var r ISZCloudResp
+		r.Data.Mobile = mobile
+		r.Data.Status = status
+		r.Data.SendNo = sendNo
+		return r
+	}
+
+	cases := []struct {
+		name string
+		resp ISZCloudResp
+		want bool
+	}{
+		{"first sighting is a transition", mkResp("13800000000", "PAYED", "1"), true},
+		{"same status/sendNo is not a transition", mkResp("13800000000", "PAYED", "1"), false},
+		{"status change is a transition", mkResp("13800000000", "SUCC", "1"), true},
+		{"sendNo change is a transition", mkResp("13800000000", "SUCC", "2"), true},
+		{"different mobile is a transition", mkResp("13900000000", "SUCC", "2"), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := store.Transitioned(c.resp); got != c.want {
+				t.Errorf("Transitioned(%+v) = %v, want %v", c.resp.Data, got, c.want)
+			}
+		})
+	}
+}
+
+func TestStateStoreSnapshot(t *testing.T) {
+	store, err := NewStateStore("")
+	if err != nil {
+		t.Fatalf("NewStateStore:%v", err)
+	}
+
+	store.Transitioned(ISZCloudResp{})
+	snap := store.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("Snapshot() len = %d, want 1", len(snap))
+	}
+}
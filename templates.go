@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates/subject.tmpl templates/body.tmpl templates/body.html.tmpl
+var defaultTemplatesFS embed.FS
+
+const (
+	defaultSubjectTemplate  = "templates/subject.tmpl"
+	defaultBodyTemplate     = "templates/body.tmpl"
+	defaultBodyHTMLTemplate = "templates/body.html.tmpl"
+)
+
+// RenderContext is the data made available to subject/body templates.
+type RenderContext struct {
+	Date    string
+	Winners []ISZCloudResp
+	Losers  []ISZCloudResp
+	Total   int
+}
+
+// BuildRenderContext splits a flat query result into winners/losers so
+// templates can highlight winners separately.
+func BuildRenderContext(result []ISZCloudResp, date string) RenderContext {
+	ctx := RenderContext{Date: date, Total: len(result)}
+	for _, r := range result {
+		if r.IsWinner() {
+			ctx.Winners = append(ctx.Winners, r)
+		} else {
+			ctx.Losers = append(ctx.Losers, r)
+		}
+	}
+	return ctx
+}
+
+func loadTextTemplate(path, embeddedName string) (*texttemplate.Template, error) {
+	if path != "" {
+		return texttemplate.ParseFiles(path)
+	}
+	content, err := defaultTemplatesFS.ReadFile(embeddedName)
+	if err != nil {
+		return nil, err
+	}
+	return texttemplate.New(embeddedName).Parse(string(content))
+}
+
+func loadHTMLTemplate(path, embeddedName string) (*htmltemplate.Template, error) {
+	if path != "" {
+		return htmltemplate.ParseFiles(path)
+	}
+	content, err := defaultTemplatesFS.ReadFile(embeddedName)
+	if err != nil {
+		return nil, err
+	}
+	return htmltemplate.New(embeddedName).Parse(string(content))
+}
+
+// RenderNotification renders subject/text/html bodies for ctx, using the
+// mail config's template paths when set, falling back to the embedded
+// defaults otherwise.
+func RenderNotification(mail Mail, ctx RenderContext) (subject, text, html string, err error) {
+	subjectTmpl, err := loadTextTemplate(mail.SubjectTemplate, defaultSubjectTemplate)
+	if err != nil {
+		return "", "", "", fmt.Errorf("render subject template:%w", err)
+	}
+	var subjectBuf bytes.Buffer
+	if err := subjectTmpl.Execute(&subjectBuf, ctx); err != nil {
+		return "", "", "", fmt.Errorf("execute subject template:%w", err)
+	}
+
+	textTmpl, err := loadTextTemplate(mail.BodyTemplate, defaultBodyTemplate)
+	if err != nil {
+		return "", "", "", fmt.Errorf("render body template:%w", err)
+	}
+	var textBuf bytes.Buffer
+	if err := textTmpl.Execute(&textBuf, ctx); err != nil {
+		return "", "", "", fmt.Errorf("execute body template:%w", err)
+	}
+
+	htmlTmpl, err := loadHTMLTemplate(mail.BodyHTMLTemplate, defaultBodyHTMLTemplate)
+	if err != nil {
+		return "", "", "", fmt.Errorf("render html body template:%w", err)
+	}
+	var htmlBuf bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlBuf, ctx); err != nil {
+		return "", "", "", fmt.Errorf("execute html body template:%w", err)
+	}
+
+	return subjectBuf.String(), textBuf.String(), htmlBuf.String(), nil
+}